@@ -0,0 +1,38 @@
+package simpleupdater
+
+import (
+	"context"
+	"os"
+	"os/exec"
+)
+
+//restartBackend abstracts the OS-specific half of a graceful
+//restart: handing open files (listening sockets, control pipes) down
+//to the freshly spawned child, and asking an already-running process
+//to begin one. master/slave and Supervisor implementations drive this
+//through currentBackend instead of talking to FDs/signals directly,
+//which is what lets sys_windows.go plug in without touching the
+//fork/exec control flow.
+type restartBackend interface {
+	//PrepareChild wires files onto cmd so the child can pick them up
+	//on start - entry i becomes file descriptor 3+i in the child,
+	//matching the numbering envNumFDs already assumes. It returns any
+	//extra environment variables the child needs to reconstruct them.
+	PrepareChild(cmd *exec.Cmd, files []*os.File) (env []string, err error)
+	//TriggerRestart asks the process identified by pid to begin a
+	//graceful restart, the same way Config.RestartSignal would on posix.
+	TriggerRestart(pid int) error
+	//TriggerDrain asks the process identified by pid to gracefully
+	//drain and exit, the same teardown Config.RestartSignal's
+	//SIGTERM-equivalent would ask a retired child to perform. Unlike
+	//TriggerRestart/TriggerDrain's posix implementations, which both
+	//reduce to real signals, Windows has no such equivalence, so this
+	//is a distinct method rather than TriggerRestart(pid) with a flag.
+	TriggerDrain(pid int) error
+	//AwaitDrain blocks the calling process until some other process
+	//calls TriggerDrain(os.Getpid()) against it, or ctx is done.
+	AwaitDrain(ctx context.Context) error
+}
+
+//currentBackend is selected per-OS in backend_posix.go/backend_windows.go.
+var currentBackend restartBackend
@@ -0,0 +1,48 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package simpleupdater
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+)
+
+func init() {
+	currentBackend = posixBackend{}
+}
+
+//posixBackend passes files to the child by FD number (the strategy
+//sys_posix.go/graceful.go already rely on) and triggers restarts by
+//sending SIGUSR2 (Config.RestartSignal's default) to the target pid.
+type posixBackend struct{}
+
+func (posixBackend) PrepareChild(cmd *exec.Cmd, files []*os.File) ([]string, error) {
+	cmd.ExtraFiles = append(cmd.ExtraFiles, files...)
+	return []string{fmt.Sprintf("%s=%s", envNumFDs, strconv.Itoa(len(files)))}, nil
+}
+
+func (posixBackend) TriggerRestart(pid int) error {
+	return syscall.Kill(pid, SIGUSR2)
+}
+
+func (posixBackend) TriggerDrain(pid int) error {
+	return syscall.Kill(pid, SIGTERM)
+}
+
+func (posixBackend) AwaitDrain(ctx context.Context) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, SIGTERM)
+	defer signal.Stop(sigCh)
+	select {
+	case <-sigCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
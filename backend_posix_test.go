@@ -0,0 +1,98 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package simpleupdater
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"testing"
+	"time"
+)
+
+func TestPosixBackendPrepareChild(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	cmd := &exec.Cmd{}
+	env, err := posixBackend{}.PrepareChild(cmd, []*os.File{r, w})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmd.ExtraFiles) != 2 {
+		t.Fatalf("expected 2 ExtraFiles, got %d", len(cmd.ExtraFiles))
+	}
+	if cmd.ExtraFiles[0] != r || cmd.ExtraFiles[1] != w {
+		t.Fatal("ExtraFiles were not appended in order")
+	}
+	want := fmt.Sprintf("%s=2", envNumFDs)
+	if len(env) != 1 || env[0] != want {
+		t.Fatalf("expected env %q, got %v", want, env)
+	}
+}
+
+func TestPosixBackendTriggerRestartNoProcess(t *testing.T) {
+	// a pid this large should never exist, so the signal must fail with ESRCH.
+	if err := (posixBackend{}).TriggerRestart(1 << 30); err == nil {
+		t.Fatal("expected an error signalling a nonexistent pid")
+	}
+}
+
+func TestPosixBackendTriggerDrainNoProcess(t *testing.T) {
+	if err := (posixBackend{}).TriggerDrain(1 << 30); err == nil {
+		t.Fatal("expected an error signalling a nonexistent pid")
+	}
+}
+
+func TestPosixBackendAwaitDrainUnblocksOnTriggerDrain(t *testing.T) {
+	// registering our own handler first disables SIGTERM's default
+	// terminate-the-process disposition for good, closing the window
+	// between spawning the goroutine below and it reaching
+	// AwaitDrain's own signal.Notify call.
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, SIGTERM)
+	defer signal.Stop(guard)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- (posixBackend{}).AwaitDrain(context.Background())
+	}()
+
+	if err := (posixBackend{}).TriggerDrain(os.Getpid()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("AwaitDrain returned %s, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("AwaitDrain did not return after TriggerDrain")
+	}
+}
+
+func TestPosixBackendAwaitDrainRespectsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- (posixBackend{}).AwaitDrain(ctx)
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("AwaitDrain returned %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("AwaitDrain did not return after ctx was cancelled")
+	}
+}
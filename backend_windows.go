@@ -0,0 +1,107 @@
+//go:build windows
+// +build windows
+
+package simpleupdater
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+func init() {
+	currentBackend = windowsBackend{}
+}
+
+//windowsBackend hands files down to the child via
+//SysProcAttr.AdditionalInheritedHandles instead of FD numbers, and
+//triggers a restart through a named event instead of SIGUSR2, since
+//Windows has neither fork/exec FD inheritance nor POSIX signals.
+type windowsBackend struct{}
+
+func (windowsBackend) PrepareChild(cmd *exec.Cmd, files []*os.File) ([]string, error) {
+	handles := make([]syscall.Handle, 0, len(files))
+	for _, f := range files {
+		handle := windows.Handle(f.Fd())
+		if err := windows.SetHandleInformation(handle, windows.HANDLE_FLAG_INHERIT, windows.HANDLE_FLAG_INHERIT); err != nil {
+			return nil, err
+		}
+		handles = append(handles, syscall.Handle(handle))
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.AdditionalInheritedHandles = handles
+	return []string{fmt.Sprintf("%s=%s", envNumFDs, strconv.Itoa(len(files)))}, nil
+}
+
+func (windowsBackend) TriggerRestart(pid int) error {
+	name := restartEventName(pid)
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	handle, err := windows.OpenEvent(windows.EVENT_MODIFY_STATE, false, namePtr)
+	if err != nil {
+		return fmt.Errorf("simpleupdater: no restart event for pid %d: %s", pid, err)
+	}
+	defer windows.CloseHandle(handle)
+	return windows.SetEvent(handle)
+}
+
+//restartEventName returns the per-process name of the manual-restart
+//event a running slave waits on in place of SIGUSR2.
+func restartEventName(pid int) string {
+	return fmt.Sprintf(`Local\simpleupdater-restart-%d`, pid)
+}
+
+func (windowsBackend) TriggerDrain(pid int) error {
+	namePtr, err := windows.UTF16PtrFromString(drainEventName(pid))
+	if err != nil {
+		return err
+	}
+	handle, err := windows.OpenEvent(windows.EVENT_MODIFY_STATE, false, namePtr)
+	if err != nil {
+		return fmt.Errorf("simpleupdater: no drain event for pid %d: %s", pid, err)
+	}
+	defer windows.CloseHandle(handle)
+	return windows.SetEvent(handle)
+}
+
+//AwaitDrain creates the named event TriggerDrain(os.Getpid()) signals
+//and blocks on it, since Windows delivers neither real SIGTERM nor
+//anything os.Process.Signal can forward - see sys_windows.go.
+func (windowsBackend) AwaitDrain(ctx context.Context) error {
+	namePtr, err := windows.UTF16PtrFromString(drainEventName(os.Getpid()))
+	if err != nil {
+		return err
+	}
+	handle, err := windows.CreateEvent(nil, 1, 0, namePtr)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(handle)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := windows.WaitForSingleObject(handle, windows.INFINITE)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//drainEventName returns the per-process name of the manual-drain
+//event a retiring child waits on in place of SIGTERM.
+func drainEventName(pid int) string {
+	return fmt.Sprintf(`Local\simpleupdater-drain-%d`, pid)
+}
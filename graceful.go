@@ -5,6 +5,7 @@ package simpleupdater
 //have been closed
 
 import (
+	"context"
 	"net"
 	"os"
 	"sync"
@@ -77,6 +78,29 @@ func (l *simpleupdaterListener) Close() error {
 	return l.closeError
 }
 
+// Drain performs a two-phase graceful close: stop accepting new
+// connections, let in-flight handlers finish on their own (same as
+// release), but force-close as soon as ctx is done rather than
+// waiting for a fixed timeout. This is what State.Listeners[i].Drain
+// is for: it lets Config.GracefulShutdown (e.g. http.Server.Shutdown)
+// and simpleupdaterConn.Close() cooperate on the same deadline instead
+// of release()'s timer slamming connections shut mid-response.
+func (l *simpleupdaterListener) Drain(ctx context.Context) error {
+	l.closeError = l.Listener.Close()
+	waited := make(chan bool)
+	go func() {
+		l.wg.Wait()
+		waited <- true
+	}()
+	select {
+	case <-ctx.Done():
+		close(l.closeByForce)
+	case <-waited:
+		//no need to force close
+	}
+	return l.closeError
+}
+
 func (l *simpleupdaterListener) File() *os.File {
 	// returns a dup(2) - FD_CLOEXEC flag *not* set
 	tl := l.Listener.(*net.TCPListener)
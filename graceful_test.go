@@ -0,0 +1,83 @@
+package simpleupdater
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDrainWaitsForInFlightConnections(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newsimpleupdaterListener(ln)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Drain(context.Background())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Drain returned before the in-flight connection was closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	accepted.Close()
+	conn.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Drain returned an error: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Drain did not return after the in-flight connection closed")
+	}
+}
+
+func TestDrainForceClosesOnContextDone(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newsimpleupdaterListener(ln)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := l.Accept(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	select {
+	case <-time.After(time.Second):
+		t.Fatal("Drain did not force-close the lingering connection in time")
+	case err := <-drainAsync(l, ctx):
+		if err != nil {
+			t.Fatalf("Drain returned an error: %s", err)
+		}
+	}
+}
+
+func drainAsync(l *simpleupdaterListener, ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- l.Drain(ctx) }()
+	return done
+}
@@ -0,0 +1,46 @@
+package simpleupdater
+
+import (
+	"fmt"
+	"os"
+)
+
+//SignatureFetcher is an optional extension of fetcher.Interface: when
+//a Config.Fetcher also implements it, InstallBinary's callers fetch
+//the matching detached signature and pass it to Config.Verifier
+//instead of installing the binary unverified. It is deliberately kept
+//separate from fetcher.Interface itself - not every Fetcher has a
+//signature to offer - the same way io.ReaderFrom is optional on top
+//of io.Reader.
+type SignatureFetcher interface {
+	//FetchSignature returns the detached signature covering the most
+	//recent binary the paired Fetch() call returned.
+	FetchSignature() ([]byte, error)
+}
+
+//InstallBinary is the single place a fetched binary goes through
+//before it replaces path: verify its signature (if Config.Verifier is
+//set), run it through PreUpgrade, back up the binary currently at
+//path (see backupBinary) and finally move the new binary into
+//place. Any failed step aborts the upgrade and leaves path untouched.
+func InstallBinary(c *Config, path, tempBinaryPath string, signature []byte) error {
+	if c.Verifier != nil {
+		if err := c.Verifier.Verify(tempBinaryPath, signature); err != nil {
+			return fmt.Errorf("simpleupdater: verification failed: %s", err)
+		}
+	}
+	if c.PreUpgrade != nil {
+		if err := c.PreUpgrade(tempBinaryPath); err != nil {
+			return fmt.Errorf("simpleupdater: PreUpgrade: %s", err)
+		}
+	}
+	if _, err := os.Stat(path); err == nil {
+		if err := backupBinary(path); err != nil {
+			return fmt.Errorf("simpleupdater: backup: %s", err)
+		}
+	}
+	if err := move(path, tempBinaryPath); err != nil {
+		return fmt.Errorf("simpleupdater: install: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package simpleupdater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeVerifier struct {
+	err    error
+	called bool
+}
+
+func (f *fakeVerifier) Verify(binaryPath string, signature []byte) error {
+	f.called = true
+	return f.err
+}
+
+func TestInstallBinaryHappyPath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app")
+	tmp := filepath.Join(dir, "app.new")
+
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tmp, []byte("new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var preUpgradeArg string
+	verifier := &fakeVerifier{}
+	c := &Config{
+		Verifier:   verifier,
+		PreUpgrade: func(p string) error { preUpgradeArg = p; return nil },
+	}
+
+	if err := InstallBinary(c, path, tmp, []byte("sig")); err != nil {
+		t.Fatalf("InstallBinary returned an error: %s", err)
+	}
+	if !verifier.called {
+		t.Fatal("Verifier was never called")
+	}
+	if preUpgradeArg != tmp {
+		t.Fatalf("PreUpgrade called with %q, want %q", preUpgradeArg, tmp)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Fatalf("path contains %q, want the new binary's contents", data)
+	}
+	backup, err := os.ReadFile(path + prevSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != "old" {
+		t.Fatalf("backup contains %q, want the old binary's contents", backup)
+	}
+}
+
+func TestInstallBinaryAbortsOnVerifierFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app")
+	tmp := filepath.Join(dir, "app.new")
+
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tmp, []byte("new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	preUpgradeCalled := false
+	c := &Config{
+		Verifier:   &fakeVerifier{err: errors.New("bad signature")},
+		PreUpgrade: func(p string) error { preUpgradeCalled = true; return nil },
+	}
+
+	if err := InstallBinary(c, path, tmp, []byte("sig")); err == nil {
+		t.Fatal("expected an error from a failing Verifier")
+	}
+	if preUpgradeCalled {
+		t.Fatal("PreUpgrade ran despite verification failing")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "old" {
+		t.Fatal("path was overwritten despite verification failing")
+	}
+	if _, err := os.Stat(path + prevSuffix); !os.IsNotExist(err) {
+		t.Fatal("a backup was made despite verification failing")
+	}
+}
+
+func TestInstallBinaryAbortsOnPreUpgradeFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app")
+	tmp := filepath.Join(dir, "app.new")
+
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tmp, []byte("new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{
+		PreUpgrade: func(p string) error { return errors.New("rejected") },
+	}
+
+	if err := InstallBinary(c, path, tmp, nil); err == nil {
+		t.Fatal("expected an error from a failing PreUpgrade")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "old" {
+		t.Fatal("path was overwritten despite PreUpgrade failing")
+	}
+}
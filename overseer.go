@@ -3,6 +3,7 @@
 package simpleupdater
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/zzzhr1990/simpleupdater/fetcher"
+	"github.com/zzzhr1990/simpleupdater/verifier"
 )
 
 const (
@@ -21,6 +23,10 @@ const (
 	envBinPath        = "simpleupdater_BIN_PATH"
 	envBinCheck       = "simpleupdater_BIN_CHECK"
 	envBinCheckLegacy = "GO_UPGRADE_BIN_CHECK"
+	//envParentPID tells a TableflipSupervisor child the pid to ask
+	//for a restart via currentBackend.TriggerRestart when Restart()
+	//is called inside it - see TableflipSupervisor.TriggerRestart.
+	envParentPID = "simpleupdater_PARENT_PID"
 )
 
 // Config defines simpleupdater's run-time configuration
@@ -59,6 +65,39 @@ type Config struct {
 	NoRestartAfterFetch bool
 	//Fetcher will be used to fetch binaries.
 	Fetcher fetcher.Interface
+	//Verifier, when set, checks a fetched binary's detached signature
+	//before PreUpgrade runs against it. A failed check aborts the
+	//upgrade, leaves the running binary untouched and is logged same
+	//as any other PreUpgrade rejection. See package verifier for the
+	//Ed25519 and Minisign implementations.
+	Verifier verifier.Interface
+	//Supervisor selects the process-supervision backend used to run
+	//Program and perform restarts. Defaults to the built-in fork/exec
+	//master/slave model; set this to e.g. NewTableflipSupervisor(&config) to
+	//keep a stable PID across upgrades.
+	Supervisor Supervisor
+	//GracefulShutdown, when set, is invoked on the slave before the
+	//TerminateTimeout force-close timer starts, e.g. wired to
+	//http.Server.Shutdown or grpc.Server.GracefulStop. It runs once
+	//per restart and should return once in-flight requests are done.
+	GracefulShutdown func(ctx context.Context) error
+	//WatchPaths, when set, makes the master watch these files/dirs
+	//and trigger a restart whenever one changes and settles for
+	//WatchDebounce, without needing a separate Fetcher. Master-only.
+	WatchPaths []string
+	//WatchDebounce is how long a watched path must stop changing
+	//before a restart is triggered. Defaults to 500ms.
+	WatchDebounce time.Duration
+	//HealthCheck, when set, is run by the slave right before it
+	//enters Config.Program, e.g. to probe that a DB connection still
+	//works. A returned error is treated the same as the slave
+	//crashing on startup: the master rolls back to the previous
+	//binary instead of leaving the bad one running.
+	HealthCheck func(state State) error
+	//HealthCheckTimeout bounds how long the master waits for a freshly
+	//spawned slave to report ready (or exit) before rolling back to
+	//the previous binary. Defaults to 30s.
+	HealthCheckTimeout time.Duration
 
 	Channel        string // update channel
 	Name           string // update Name
@@ -87,6 +126,9 @@ func validate(c *Config) error {
 	if c.MinFetchInterval <= 0 {
 		c.MinFetchInterval = 1 * time.Second
 	}
+	if c.HealthCheckTimeout <= 0 {
+		c.HealthCheckTimeout = 30 * time.Second
+	}
 	return nil
 }
 
@@ -146,6 +188,11 @@ var currentProcess interface {
 	run() error
 }
 
+// currentConfig is the Config of the currently running master/slave
+// or Supervisor, kept so helpers like State.Ready can reach it
+// without threading Config through every call site.
+var currentConfig *Config
+
 func runErr(c *Config) error {
 	//os not supported
 	if !supported {
@@ -154,14 +201,31 @@ func runErr(c *Config) error {
 	if err := validate(c); err != nil {
 		return err
 	}
+	currentConfig = c
 	if sanityCheck() {
 		return nil
 	}
+	//a custom Supervisor takes over the whole run, bypassing the
+	//built-in master/slave fork-exec model entirely. It still goes
+	//through currentProcess so Restart()/watch.go's triggerRestart()
+	//call reach it like they would a master/slave.
+	if c.Supervisor != nil {
+		currentProcess = &supervisorProcess{Supervisor: c.Supervisor}
+		if os.Getenv(envIsSlave) != "1" {
+			if err := startWatcher(c); err != nil {
+				return err
+			}
+		}
+		return currentProcess.run()
+	}
 	//run either in master or slave mode
 	if os.Getenv(envIsSlave) == "1" {
 		currentProcess = &slave{Config: c}
 	} else {
 		currentProcess = &master{Config: c}
+		if err := startWatcher(c); err != nil {
+			return err
+		}
 	}
 	return currentProcess.run()
 }
@@ -0,0 +1,66 @@
+package simpleupdater
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+//prevSuffix names the backup backupBinary keeps of the binary move()
+//is about to overwrite, so a failed upgrade can be rolled back to it.
+const prevSuffix = ".prev"
+
+//backupBinary copies path to path+prevSuffix before move() overwrites
+//it, so a slave that fails its health check can be rolled back to a
+//known-good binary instead of leaving the bad one running.
+func backupBinary(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+prevSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+	return dst.Close()
+}
+
+//rollbackBinary restores path+prevSuffix over path via move(), the
+//same primitive used to install upgrades, and logs the rollback.
+func rollbackBinary(path string) error {
+	log.Printf("[simpleupdater] rollback")
+	return move(path, path+prevSuffix)
+}
+
+//awaitHealthy blocks until ready reports the freshly spawned slave is
+//healthy, the slave exits, or timeout elapses. A nil error means the
+//slave is healthy and the master can safely leave it running; any
+//other outcome means the caller should roll back.
+func awaitHealthy(ready <-chan error, timeout time.Duration) error {
+	select {
+	case err := <-ready:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("simpleupdater: health check timed out after %s", timeout)
+	}
+}
+
+//runHealthCheck runs Config.HealthCheck, if set, against state. It is
+//called from slave.run() right before Config.Program, so the result
+//can be reported back to the master over the control pipe before the
+//slave commits to running the new binary.
+func runHealthCheck(c *Config, state State) error {
+	if c.HealthCheck == nil {
+		return nil
+	}
+	return c.HealthCheck(state)
+}
@@ -0,0 +1,71 @@
+package simpleupdater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupAndRollbackRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app")
+
+	if err := os.WriteFile(path, []byte("good"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := backupBinary(path); err != nil {
+		t.Fatalf("backupBinary: %s", err)
+	}
+	if err := os.WriteFile(path, []byte("bad"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rollbackBinary(path); err != nil {
+		t.Fatalf("rollbackBinary: %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "good" {
+		t.Fatalf("path contains %q after rollback, want the backed-up contents", data)
+	}
+}
+
+func TestAwaitHealthyReturnsReadyResult(t *testing.T) {
+	ready := make(chan error, 1)
+	ready <- nil
+	if err := awaitHealthy(ready, time.Second); err != nil {
+		t.Fatalf("awaitHealthy returned %s, want nil", err)
+	}
+
+	want := errors.New("unhealthy: boom")
+	ready = make(chan error, 1)
+	ready <- want
+	if err := awaitHealthy(ready, time.Second); err != want {
+		t.Fatalf("awaitHealthy returned %v, want %v", err, want)
+	}
+}
+
+func TestAwaitHealthyTimesOut(t *testing.T) {
+	ready := make(chan error)
+	if err := awaitHealthy(ready, 20*time.Millisecond); err == nil {
+		t.Fatal("expected a timeout error")
+	}
+}
+
+func TestRunHealthCheckNilIsNoop(t *testing.T) {
+	if err := runHealthCheck(&Config{}, State{}); err != nil {
+		t.Fatalf("runHealthCheck with no HealthCheck configured returned %s", err)
+	}
+}
+
+func TestRunHealthCheckPropagatesFailure(t *testing.T) {
+	want := errors.New("not listening")
+	c := &Config{HealthCheck: func(State) error { return want }}
+	if err := runHealthCheck(c, State{}); err != want {
+		t.Fatalf("runHealthCheck returned %v, want %v", err, want)
+	}
+}
@@ -0,0 +1,13 @@
+package simpleupdater
+
+//Ready signals that this process has finished taking over its
+//listeners and Config.Program is safe to be considered live.
+//slave.run() calls this right before entering Config.Program so a
+//Supervisor (see TableflipSupervisor) knows it can release the
+//parent's hold on the old listeners.
+func (s State) Ready() error {
+	if currentConfig != nil && currentConfig.Supervisor != nil {
+		return currentConfig.Supervisor.Ready()
+	}
+	return nil
+}
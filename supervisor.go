@@ -0,0 +1,37 @@
+package simpleupdater
+
+//Supervisor is the pluggable process-supervision backend behind
+//Config.Supervisor. The built-in master/slave fork-exec model
+//satisfies it implicitly through currentProcess; alternative
+//backends (see NewTableflipSupervisor) implement it directly so
+//they can be swapped in without touching runErr's call sites.
+type Supervisor interface {
+	//Run starts the supervisor and blocks until the supervised
+	//program exits, the same contract as currentProcess.run().
+	Run() error
+	//TriggerRestart begins a graceful restart, the Supervisor
+	//equivalent of Config.RestartSignal/Restart().
+	TriggerRestart()
+	//Ready is called by the supervised child once Config.Program
+	//has taken over its listeners, letting the supervisor know it
+	//can safely release its own hold on them.
+	Ready() error
+	//Exit stops the supervisor, releasing any listeners it still
+	//owns without spawning a replacement.
+	Exit()
+}
+
+//supervisorProcess adapts a Config.Supervisor to the currentProcess
+//interface, so Restart() and watch.go's onWatchedChange can reach a
+//Supervisor-based run the same way they already reach master/slave.
+type supervisorProcess struct {
+	Supervisor Supervisor
+}
+
+func (p *supervisorProcess) run() error {
+	return p.Supervisor.Run()
+}
+
+func (p *supervisorProcess) triggerRestart() {
+	p.Supervisor.TriggerRestart()
+}
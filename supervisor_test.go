@@ -0,0 +1,43 @@
+package simpleupdater
+
+import (
+	"errors"
+	"testing"
+)
+
+//fakeSupervisor is a minimal Supervisor stand-in for exercising
+//supervisorProcess without spawning any real processes.
+type fakeSupervisor struct {
+	runErr           error
+	restartTriggered bool
+}
+
+func (f *fakeSupervisor) Run() error      { return f.runErr }
+func (f *fakeSupervisor) TriggerRestart() { f.restartTriggered = true }
+func (f *fakeSupervisor) Ready() error    { return nil }
+func (f *fakeSupervisor) Exit()           {}
+
+func TestSupervisorProcessRunReachesSupervisor(t *testing.T) {
+	// Config.Supervisor's Run() is where spawnChild's health-check
+	// rollback lives (see TableflipSupervisor.spawnChild); before
+	// runErr wired currentProcess to a Supervisor, nothing ever called
+	// it, so that protection guarded no reachable upgrade flow.
+	want := errors.New("health check failed, rolled back")
+	fs := &fakeSupervisor{runErr: want}
+	p := &supervisorProcess{Supervisor: fs}
+
+	if err := p.run(); err != want {
+		t.Fatalf("run() = %v, want %v", err, want)
+	}
+}
+
+func TestSupervisorProcessTriggerRestartReachesSupervisor(t *testing.T) {
+	fs := &fakeSupervisor{}
+	p := &supervisorProcess{Supervisor: fs}
+
+	p.triggerRestart()
+
+	if !fs.restartTriggered {
+		t.Fatal("expected triggerRestart() to call through to Supervisor.TriggerRestart()")
+	}
+}
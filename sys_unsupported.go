@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !windows
+// +build !linux,!darwin,!freebsd,!windows
+
+package simpleupdater
+
+//this file is the fallback for any OS that has no
+//dedicated sys_*.go implementation yet.
+
+var supported = false
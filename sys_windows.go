@@ -0,0 +1,55 @@
+//go:build windows
+// +build windows
+
+package simpleupdater
+
+//this file contains the Windows equivalents of the
+//posix-specific behaviour in sys_posix.go. Windows has
+//no fork/exec, no SIGUSR2 and no FD-inheritance-by-number,
+//so restart and handle-passing are handled separately by
+//the windowsBackend in backend_windows.go - this file only
+//covers the bits sys_posix.go also covers: move/chmod/chown
+//and the signal placeholders used by Config.RestartSignal.
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	supported = true
+	uid       = 0
+	gid       = 0
+	//Windows has no SIGUSR1/SIGUSR2, these exist purely so
+	//Config.RestartSignal/validate() keep compiling unchanged.
+	//The actual manual-restart trigger on Windows goes through
+	//windowsBackend's named event, not through os.Signal delivery.
+	SIGUSR1 = syscall.Signal(0x100)
+	SIGUSR2 = syscall.Signal(0x101)
+	SIGTERM = syscall.Signal(0x102)
+)
+
+func move(dst, src string) error {
+	from, err := windows.UTF16PtrFromString(src)
+	if err != nil {
+		return err
+	}
+	to, err := windows.UTF16PtrFromString(dst)
+	if err != nil {
+		return err
+	}
+	flags := uint32(windows.MOVEFILE_REPLACE_EXISTING | windows.MOVEFILE_WRITE_THROUGH)
+	return windows.MoveFileEx(from, to, flags)
+}
+
+func chmod(f *os.File, perms os.FileMode) error {
+	//no POSIX permission bits on Windows, nothing to do.
+	return nil
+}
+
+func chown(f *os.File, uid, gid int) error {
+	//no POSIX ownership on Windows, nothing to do.
+	return nil
+}
@@ -0,0 +1,435 @@
+package simpleupdater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//childProc is a running child plus its eventual exit status. done is
+//closed (not sent on) once cmd.Wait() returns, so both spawnChild's
+//own health-check watcher and the caller that later wants to block
+//on the child's exit can each read it independently.
+type childProc struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+func (p *childProc) wait() error {
+	<-p.done
+	return p.err
+}
+
+//TableflipSupervisor is a Supervisor that keeps one long-lived
+//parent process alive across every restart, instead of the default
+//master/slave model re-execing itself in place. The parent owns the
+//listeners, spawns a child that inherits them plus a dedicated ready
+//pipe, and only retires the previous child once the new one writes
+//"ready" down that pipe - so the OS-visible PID never changes, which
+//is what lets systemd/supervisord supervise it without Restart=always
+//firing on every upgrade.
+type TableflipSupervisor struct {
+	Config *Config
+
+	mu        sync.Mutex
+	listeners []*simpleupdaterListener
+	child     *childProc
+
+	//readyW and parentPID are set in the child process only: the
+	//write end of the ready pipe inherited from the parent (written
+	//to by Ready()), and the parent's pid, used to ask it for a
+	//restart via currentBackend.TriggerRestart when Restart() is
+	//called in this process - see TriggerRestart.
+	readyW    *os.File
+	parentPID int
+}
+
+//NewTableflipSupervisor builds a Supervisor for Config.Supervisor.
+func NewTableflipSupervisor(c *Config) *TableflipSupervisor {
+	return &TableflipSupervisor{Config: c}
+}
+
+func (t *TableflipSupervisor) Run() error {
+	if os.Getenv(envIsSlave) == "1" {
+		return t.runChild()
+	}
+	return t.runParent()
+}
+
+func (t *TableflipSupervisor) runParent() error {
+	//t.Config is the caller's own Config, not the copy runErr
+	//validated, so Address may not have been folded into Addresses.
+	addrs := t.Config.Addresses
+	if len(addrs) == 0 && t.Config.Address != "" {
+		addrs = []string{t.Config.Address}
+	}
+	for _, addr := range addrs {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("simpleupdater: tableflip: %s", err)
+		}
+		t.listeners = append(t.listeners, newsimpleupdaterListener(l))
+	}
+	child, err := t.spawnChild()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.child = child
+	t.mu.Unlock()
+
+	go t.fetchLoop()
+
+	//a child's TriggerRestart reaches this process as SIGUSR2 (see
+	//posixBackend.TriggerRestart); spawn the replacement from here.
+	restartCh := make(chan os.Signal, 1)
+	signal.Notify(restartCh, SIGUSR2)
+	go func() {
+		for range restartCh {
+			t.TriggerRestart()
+		}
+	}()
+
+	//Keep waiting on whichever child is current, not just the one
+	//spawned above: TriggerRestart retires old children by replacing
+	//t.child and then asking them to drain, so their exit is expected
+	//and must not be mistaken for the whole supervisor exiting.
+	for {
+		active := t.activeChild()
+		if err, done := waitForExit(active, t.activeChild); done {
+			return err
+		}
+	}
+}
+
+//waitForExit blocks until child exits, then reports whether it was
+//still the active one at that point via current(). A child that was
+//replaced (TriggerRestart swapped in a new one before asking the old
+//one to drain) reports done=false so runParent's loop goes around
+//again instead of treating an expected retirement as the whole
+//supervisor exiting.
+func waitForExit(child *childProc, current func() *childProc) (err error, done bool) {
+	err = child.wait()
+	if current() != child {
+		return nil, false
+	}
+	return err, true
+}
+
+func (t *TableflipSupervisor) activeChild() *childProc {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.child
+}
+
+//runChild reconstructs the inherited listeners and ready pipe, runs
+//Config.Program against them, and returns once Program does.
+//Config.Program is expected to call state.Ready() once it has bound
+//the listeners, which crosses back to the parent over the pipe.
+func (t *TableflipSupervisor) runChild() error {
+	listeners, readyW, err := inheritTableflipFiles()
+	if err != nil {
+		return err
+	}
+	t.readyW = readyW
+	t.parentPID, _ = strconv.Atoi(os.Getenv(envParentPID))
+
+	state := State{Listeners: listeners}
+	if err := runHealthCheck(t.Config, state); err != nil {
+		fmt.Fprintf(readyW, "unhealthy: %s", err)
+		return err
+	}
+
+	//retired by TriggerRestart/Exit asking this pid to drain once its
+	//replacement (or nothing, on Exit) has taken over.
+	go func() {
+		if err := currentBackend.AwaitDrain(context.Background()); err == nil {
+			t.drain(listeners)
+		}
+	}()
+
+	t.Config.Program(state)
+	return nil
+}
+
+//drain runs Config.GracefulShutdown and then drains every listener,
+//giving in-flight requests up to TerminateTimeout to finish before
+//Drain's force-close kicks in.
+func (t *TableflipSupervisor) drain(listeners []*simpleupdaterListener) {
+	ctx := context.Background()
+	if t.Config.TerminateTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Config.TerminateTimeout)
+		defer cancel()
+	}
+	if t.Config.GracefulShutdown != nil {
+		if err := t.Config.GracefulShutdown(ctx); err != nil {
+			log.Printf("[simpleupdater] tableflip: GracefulShutdown: %s", err)
+		}
+	}
+	for _, l := range listeners {
+		l.Drain(ctx)
+	}
+}
+
+//inheritTableflipFiles reconstructs the listeners and ready pipe a
+//parent TableflipSupervisor passed down via currentBackend.PrepareChild:
+//entry i is file descriptor 3+i, with the ready pipe last.
+func inheritTableflipFiles() ([]*simpleupdaterListener, *os.File, error) {
+	numFDs, _ := strconv.Atoi(os.Getenv(envNumFDs))
+	if numFDs < 1 {
+		return nil, nil, fmt.Errorf("simpleupdater: tableflip: child started without a ready pipe")
+	}
+	listenerCount := numFDs - 1
+	listeners := make([]*simpleupdaterListener, 0, listenerCount)
+	for i := 0; i < listenerCount; i++ {
+		f := os.NewFile(uintptr(3+i), fmt.Sprintf("simpleupdater-listener-%d", i))
+		l, err := net.FileListener(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("simpleupdater: tableflip: %s", err)
+		}
+		listeners = append(listeners, newsimpleupdaterListener(l))
+	}
+	readyW := os.NewFile(uintptr(3+listenerCount), "simpleupdater-ready")
+	return listeners, readyW, nil
+}
+
+//spawnChild starts a new child with the parent's listeners plus a
+//dedicated ready pipe, and blocks until the child writes to that
+//pipe, exits, or HealthCheckTimeout elapses - whichever comes first.
+//If the child reports "unhealthy", exits or times out, it is killed
+//and, since the binary it just ran is presumed bad, rolled back via
+//rollbackBinary.
+func (t *TableflipSupervisor) spawnChild() (*childProc, error) {
+	bin, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("simpleupdater: tableflip: %s", err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("simpleupdater: tableflip: %s", err)
+	}
+	defer r.Close()
+
+	cmd := exec.Command(bin, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), envIsSlave+"=1", fmt.Sprintf("%s=%d", envParentPID, os.Getpid()))
+	cmd.Stdout, cmd.Stderr, cmd.Stdin = os.Stdout, os.Stderr, os.Stdin
+
+	files := make([]*os.File, 0, len(t.listeners)+1)
+	for _, l := range t.listeners {
+		files = append(files, l.File())
+	}
+	files = append(files, w)
+	extraEnv, err := currentBackend.PrepareChild(cmd, files)
+	if err != nil {
+		return nil, fmt.Errorf("simpleupdater: tableflip: %s", err)
+	}
+	cmd.Env = append(cmd.Env, extraEnv...)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("simpleupdater: tableflip: %s", err)
+	}
+	w.Close()
+
+	child := &childProc{cmd: cmd, done: make(chan struct{})}
+	go func() {
+		child.err = cmd.Wait()
+		close(child.done)
+	}()
+
+	//ready carries the outcome the child reported over the pipe (nil
+	//for "ready", an error for "unhealthy: ...") or, once child.done
+	//closes, the reason it exited. Reading child.done here doesn't
+	//consume anything - it's a broadcast close, so the caller can
+	//still block on child.wait() later regardless of which fires first.
+	ready := make(chan error, 2)
+	go func() {
+		buf := make([]byte, 256)
+		n, err := r.Read(buf)
+		if err != nil && err != io.EOF {
+			ready <- err
+			return
+		}
+		if n == 0 {
+			//pipe closed without a report: the child exited (or gave
+			//up the pipe) before becoming ready, which the exit watcher
+			//below or the timeout reports.
+			return
+		}
+		if msg := string(buf[:n]); strings.HasPrefix(msg, "unhealthy") {
+			ready <- errors.New(msg)
+			return
+		}
+		ready <- nil
+	}()
+	go func() {
+		<-child.done
+		ready <- fmt.Errorf("child exited before becoming ready: %v", child.err)
+	}()
+
+	timeout := t.Config.HealthCheckTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	if err := awaitHealthy(ready, timeout); err != nil {
+		cmd.Process.Kill()
+		child.wait()
+		if rbErr := rollbackBinary(bin); rbErr != nil {
+			return nil, fmt.Errorf("simpleupdater: tableflip: %s, and rollback failed: %s", err, rbErr)
+		}
+		return nil, fmt.Errorf("simpleupdater: tableflip: %s", err)
+	}
+	return child, nil
+}
+
+//TriggerRestart is called on two very different instances depending
+//on which process it runs in: in the parent it spawns a replacement
+//child and retires the old one, same as a fetchLoop upgrade; in the
+//child (readyW set) it can't spawn its own sibling, so it instead
+//asks the parent - identified by envParentPID - to do so via the
+//same currentBackend.TriggerRestart(pid) a master/slave restart uses.
+func (t *TableflipSupervisor) TriggerRestart() {
+	if t.readyW != nil {
+		if t.parentPID == 0 {
+			log.Printf("[simpleupdater] tableflip: restart requested but no parent pid was inherited")
+			return
+		}
+		if err := currentBackend.TriggerRestart(t.parentPID); err != nil {
+			log.Printf("[simpleupdater] tableflip: restart request failed: %s", err)
+		}
+		return
+	}
+
+	if err := t.restartParent(t.spawnChild); err != nil {
+		log.Printf("[simpleupdater] tableflip: restart failed: %s", err)
+	}
+}
+
+//restartParent spawns a replacement child via spawn and, only once
+//that succeeds, swaps it in as the active child and asks the previous
+//one to drain. A failed spawn leaves the previous child running and
+//active - the same "leave the working binary alone" guarantee
+//spawnChild's own health-check rollback gives a first start, now also
+//held on every later restart.
+func (t *TableflipSupervisor) restartParent(spawn func() (*childProc, error)) error {
+	old := t.activeChild()
+
+	child, err := spawn()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.child = child
+	t.mu.Unlock()
+
+	if old != nil && old.cmd != nil && old.cmd.Process != nil {
+		if err := currentBackend.TriggerDrain(old.cmd.Process.Pid); err != nil {
+			log.Printf("[simpleupdater] tableflip: asking the old child to drain: %s", err)
+		}
+	}
+	return nil
+}
+
+//fetchLoop polls Config.Fetcher for a new binary on Config.MinFetchInterval,
+//installs whatever it returns via InstallBinary and, unless
+//NoRestartAfterFetch is set, restarts into it via TriggerRestart. It is
+//the Supervisor-mode equivalent of the automatic-upgrade loop the
+//built-in master/slave model runs, and is a no-op when Fetcher is unset.
+func (t *TableflipSupervisor) fetchLoop() {
+	if t.Config.Fetcher == nil {
+		return
+	}
+	interval := t.Config.MinFetchInterval
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+	for {
+		time.Sleep(interval)
+		r, err := t.Config.Fetcher.Fetch()
+		if err != nil {
+			if t.Config.Debug {
+				log.Printf("[simpleupdater] tableflip: fetch: %s", err)
+			}
+			continue
+		}
+		if r == nil {
+			continue // no update available
+		}
+		if err := t.installFetched(r); err != nil {
+			log.Printf("[simpleupdater] tableflip: %s", err)
+			continue
+		}
+		if !t.Config.NoRestartAfterFetch {
+			t.TriggerRestart()
+		}
+	}
+}
+
+//installFetched writes a fetched binary to a temp file next to the
+//current executable and runs it through InstallBinary - the same
+//verify/PreUpgrade/backup/move path onWatchedChange uses for a watched
+//file change. If Config.Fetcher also implements SignatureFetcher, the
+//matching detached signature is fetched and checked too.
+func (t *TableflipSupervisor) installFetched(r io.Reader) error {
+	bin, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(bin), "simpleupdater-fetch-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+
+	var signature []byte
+	if sf, ok := t.Config.Fetcher.(SignatureFetcher); ok {
+		signature, err = sf.FetchSignature()
+		if err != nil {
+			return fmt.Errorf("simpleupdater: fetch signature: %s", err)
+		}
+	}
+	return InstallBinary(t.Config, bin, tmp.Name(), signature)
+}
+
+func (t *TableflipSupervisor) Ready() error {
+	if t.readyW == nil {
+		return nil
+	}
+	_, err := fmt.Fprint(t.readyW, "ready")
+	return err
+}
+
+func (t *TableflipSupervisor) Exit() {
+	for _, l := range t.listeners {
+		l.Listener.Close()
+	}
+	child := t.activeChild()
+	if child == nil || child.cmd.Process == nil {
+		return
+	}
+	if err := currentBackend.TriggerDrain(child.cmd.Process.Pid); err != nil {
+		log.Printf("[simpleupdater] tableflip: asking the child to drain: %s", err)
+	}
+}
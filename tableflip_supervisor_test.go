@@ -0,0 +1,63 @@
+package simpleupdater
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWaitForExitReturnsWhenStillActive(t *testing.T) {
+	child := &childProc{done: make(chan struct{})}
+	child.err = errors.New("boom")
+	close(child.done)
+
+	err, done := waitForExit(child, func() *childProc { return child })
+	if !done {
+		t.Fatal("expected done=true when the exited child is still active")
+	}
+	if err != child.err {
+		t.Fatalf("err = %v, want %v", err, child.err)
+	}
+}
+
+func TestWaitForExitIgnoresRetiredChild(t *testing.T) {
+	retired := &childProc{done: make(chan struct{})}
+	retired.err = errors.New("retired child exiting after being replaced")
+	close(retired.done)
+
+	replacement := &childProc{done: make(chan struct{})}
+
+	err, done := waitForExit(retired, func() *childProc { return replacement })
+	if done {
+		t.Fatal("expected done=false when a replacement has taken over")
+	}
+	if err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+}
+
+func TestRestartParentKeepsOldChildOnSpawnFailure(t *testing.T) {
+	old := &childProc{done: make(chan struct{})}
+	ts := &TableflipSupervisor{child: old}
+
+	spawnErr := errors.New("spawn failed")
+	err := ts.restartParent(func() (*childProc, error) { return nil, spawnErr })
+	if err != spawnErr {
+		t.Fatalf("err = %v, want %v", err, spawnErr)
+	}
+	if ts.activeChild() != old {
+		t.Fatal("expected the previous child to remain active after a failed restart")
+	}
+}
+
+func TestRestartParentSwapsInSuccessfulChild(t *testing.T) {
+	old := &childProc{done: make(chan struct{})}
+	ts := &TableflipSupervisor{child: old}
+
+	next := &childProc{done: make(chan struct{})}
+	if err := ts.restartParent(func() (*childProc, error) { return next, nil }); err != nil {
+		t.Fatalf("restartParent returned %s, want nil", err)
+	}
+	if ts.activeChild() != next {
+		t.Fatal("expected the newly spawned child to become active")
+	}
+}
@@ -0,0 +1,28 @@
+package verifier
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"os"
+)
+
+// Ed25519 verifies a fetched binary against a raw detached Ed25519
+// signature, i.e. signature is exactly ed25519.SignatureSize bytes
+// produced by ed25519.Sign over the binary's contents.
+type Ed25519 struct {
+	PublicKey ed25519.PublicKey
+}
+
+func (v Ed25519) Verify(binaryPath string, signature []byte) error {
+	if len(v.PublicKey) != ed25519.PublicKeySize {
+		return errors.New("verifier: ed25519 public key has the wrong size")
+	}
+	data, err := os.ReadFile(binaryPath)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(v.PublicKey, data, signature) {
+		return errors.New("verifier: ed25519 signature verification failed")
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+// Package verifier implements Config.Verifier backends that check a
+// fetched binary's detached signature before simpleupdater lets it
+// replace the running one.
+package verifier
+
+// Interface is the contract simpleupdater's PreUpgrade pipeline calls
+// after a Fetcher downloads a new binary and its detached signature.
+// A non-nil error aborts the upgrade and keeps the old binary.
+type Interface interface {
+	Verify(binaryPath string, signature []byte) error
+}
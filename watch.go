@@ -0,0 +1,101 @@
+package simpleupdater
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+//startWatcher spawns an fsnotify watcher over Config.WatchPaths in
+//the master process, letting simpleupdater double as a dev-mode
+//hot-reloader without wiring a separate Fetcher. It is a no-op
+//unless WatchPaths is set, and must never run in a slave (guarded by
+//the envIsSlave check in runErr before this is called).
+func startWatcher(c *Config) error {
+	if len(c.WatchPaths) == 0 {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	for _, path := range c.WatchPaths {
+		if err := watcher.Add(path); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+	go watchLoop(c, watcher)
+	return nil
+}
+
+func watchLoop(c *Config, watcher *fsnotify.Watcher) {
+	watchLoopFunc(c, watcher, onWatchedChange)
+}
+
+//watchLoopFunc is watchLoop with onChange broken out as a parameter
+//so the debounce logic can be exercised without touching the real
+//executable via onWatchedChange's InstallBinary call.
+func watchLoopFunc(c *Config, watcher *fsnotify.Watcher, onChange func(*Config, string)) {
+	debounce := c.WatchDebounce
+	if debounce <= 0 {
+		debounce = 500 * time.Millisecond
+	}
+	var timer *time.Timer
+	var pending string
+	for {
+		select {
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			pending = ev.Name
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(debounce, func() {
+				onChange(c, pending)
+			})
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			if c.Debug {
+				log.Printf("[simpleupdater] watch: %s", err)
+			}
+		}
+	}
+}
+
+//execOverride stands in for os.Executable in tests, which can't
+//otherwise redirect onWatchedChange's install target to a temp file.
+var execOverride = os.Executable
+
+//onWatchedChange installs the changed binary at path over the
+//currently running executable via InstallBinary - the same
+//PreUpgrade/backup/move path a fetched binary goes through, just
+//without a signature to verify - before triggering a restart. A
+//rejected or failed install leaves the running binary untouched.
+func onWatchedChange(c *Config, path string) {
+	bin, err := execOverride()
+	if err != nil {
+		if c.Debug {
+			log.Printf("[simpleupdater] watch: %s", err)
+		}
+		return
+	}
+	if err := InstallBinary(c, bin, path, nil); err != nil {
+		if c.Debug || !c.NoWarn {
+			log.Printf("[simpleupdater] watch: %s", err)
+		}
+		return
+	}
+	if currentProcess != nil {
+		currentProcess.triggerRestart()
+	}
+}
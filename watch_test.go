@@ -0,0 +1,146 @@
+package simpleupdater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatchLoopDebouncesRapidEvents(t *testing.T) {
+	calls := make(chan string, 10)
+	c := &Config{WatchDebounce: 30 * time.Millisecond}
+	watcher := &fsnotify.Watcher{
+		Events: make(chan fsnotify.Event),
+		Errors: make(chan error),
+	}
+	go watchLoopFunc(c, watcher, func(_ *Config, path string) { calls <- path })
+
+	watcher.Events <- fsnotify.Event{Name: "a", Op: fsnotify.Write}
+	watcher.Events <- fsnotify.Event{Name: "b", Op: fsnotify.Write}
+	watcher.Events <- fsnotify.Event{Name: "c", Op: fsnotify.Write}
+
+	select {
+	case <-calls:
+		t.Fatal("onChange fired before the debounce window elapsed")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	select {
+	case path := <-calls:
+		if path != "c" {
+			t.Fatalf("onChange called with %q, want the last event's path %q", path, "c")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("onChange never fired")
+	}
+
+	select {
+	case path := <-calls:
+		t.Fatalf("onChange fired a second time with %q, want exactly one call", path)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchLoopIgnoresNonWriteEvents(t *testing.T) {
+	calls := make(chan string, 10)
+	c := &Config{WatchDebounce: 10 * time.Millisecond}
+	watcher := &fsnotify.Watcher{
+		Events: make(chan fsnotify.Event),
+		Errors: make(chan error),
+	}
+	go watchLoopFunc(c, watcher, func(_ *Config, path string) { calls <- path })
+
+	watcher.Events <- fsnotify.Event{Name: "a", Op: fsnotify.Remove}
+
+	select {
+	case path := <-calls:
+		t.Fatalf("onChange fired for a Remove event with %q, want it ignored", path)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestOnWatchedChangeInstallsThenRestarts(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "app")
+	if err := os.WriteFile(bin, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	changed := filepath.Join(dir, "app.new")
+	if err := os.WriteFile(changed, []byte("new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := false
+	prev := currentProcess
+	currentProcess = fakeCurrentProcess{restart: func() { restarted = true }}
+	defer func() { currentProcess = prev }()
+
+	withExecutable(t, bin, func() {
+		onWatchedChange(&Config{}, changed)
+	})
+
+	if !restarted {
+		t.Fatal("triggerRestart was never called after a successful install")
+	}
+	data, err := os.ReadFile(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Fatalf("running binary contains %q, want the watched file's new contents", data)
+	}
+}
+
+func TestOnWatchedChangeSkipsRestartOnRejectedPreUpgrade(t *testing.T) {
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "app")
+	if err := os.WriteFile(bin, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	changed := filepath.Join(dir, "app.new")
+	if err := os.WriteFile(changed, []byte("new"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := false
+	prev := currentProcess
+	currentProcess = fakeCurrentProcess{restart: func() { restarted = true }}
+	defer func() { currentProcess = prev }()
+
+	c := &Config{PreUpgrade: func(string) error { return errors.New("rejected") }}
+	withExecutable(t, bin, func() {
+		onWatchedChange(c, changed)
+	})
+
+	if restarted {
+		t.Fatal("triggerRestart was called despite PreUpgrade rejecting the change")
+	}
+	data, err := os.ReadFile(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "old" {
+		t.Fatal("running binary was overwritten despite PreUpgrade rejecting the change")
+	}
+}
+
+type fakeCurrentProcess struct {
+	restart func()
+}
+
+func (f fakeCurrentProcess) run() error      { return nil }
+func (f fakeCurrentProcess) triggerRestart() { f.restart() }
+
+//withExecutable points onWatchedChange's execOverride seam at bin
+//for the duration of fn, restoring it afterwards.
+func withExecutable(t *testing.T, bin string, fn func()) {
+	t.Helper()
+	prev := execOverride
+	execOverride = func() (string, error) { return bin, nil }
+	defer func() { execOverride = prev }()
+	fn()
+}